@@ -0,0 +1,18 @@
+// Command sshmenu-trigger pings a running sshmenu agent to pop up the menu.
+// It's meant to be bound to a global hotkey (sxhkd, Hammerspoon, PowerToys)
+// rather than run interactively.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vorn003/SSHMenu/service"
+)
+
+func main() {
+	if err := service.Trigger(); err != nil {
+		fmt.Println("sshmenu-trigger:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,186 @@
+// Package history persists which servers sshmenu's user has favorited and
+// recently (or unsuccessfully) connected to, so the search UI can surface
+// them ahead of a plain fuzzy match.
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// MaxRecents bounds how many recently-used servers RecentRank/Recents will
+// consider; older entries still persist but stop counting as "recent".
+const MaxRecents = 20
+
+// Entry is one server's recorded usage, keyed by its name.
+type Entry struct {
+	Name       string    `json:"name"`
+	LastUsed   time.Time `json:"last_used,omitempty"`
+	Favorite   bool      `json:"favorite,omitempty"`
+	LastFailed bool      `json:"last_failed,omitempty"`
+}
+
+// Store is the favorites/recents state, loaded from and saved back to a
+// JSON file under the XDG config dir.
+type Store struct {
+	path    string
+	entries map[string]*Entry
+}
+
+// DefaultPath returns ~/.config/sshmenu/history.json.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "sshmenu", "history.json"), nil
+}
+
+// Load reads the store at path. A missing file is treated as an empty
+// store, not an error, since that's the state of a fresh install.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, entries: map[string]*Entry{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		e := list[i]
+		s.entries[e.Name] = &e
+	}
+	return s, nil
+}
+
+// Save writes the store back to its path, creating the parent directory if
+// it doesn't exist yet.
+func (s *Store) Save() error {
+	list := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, *e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsed.After(list[j].LastUsed) })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+func (s *Store) entry(name string) *Entry {
+	e, ok := s.entries[name]
+	if !ok {
+		e = &Entry{Name: name}
+		s.entries[name] = e
+	}
+	return e
+}
+
+// MarkUsed records a launch of name at the given time as its most recent
+// use, clearing any previous failure.
+func (s *Store) MarkUsed(name string, at time.Time) {
+	e := s.entry(name)
+	e.LastUsed = at
+	e.LastFailed = false
+}
+
+// MarkFailed records that the last launch of name failed.
+func (s *Store) MarkFailed(name string) {
+	s.entry(name).LastFailed = true
+}
+
+// ToggleFavorite flips name's favorite flag and returns the new state.
+func (s *Store) ToggleFavorite(name string) bool {
+	e := s.entry(name)
+	e.Favorite = !e.Favorite
+	return e.Favorite
+}
+
+// IsFavorite reports whether name is favorited.
+func (s *Store) IsFavorite(name string) bool {
+	e, ok := s.entries[name]
+	return ok && e.Favorite
+}
+
+// LastFailed reports whether name's last recorded launch failed.
+func (s *Store) LastFailed(name string) bool {
+	e, ok := s.entries[name]
+	return ok && e.LastFailed
+}
+
+// Forget removes name from the recent-use list. If name is a favorite, the
+// favorite flag is kept (forgetting history isn't the same as unfavoriting)
+// and only its recency is cleared; otherwise the entry is dropped entirely.
+func (s *Store) Forget(name string) {
+	e, ok := s.entries[name]
+	if !ok {
+		return
+	}
+	if e.Favorite {
+		e.LastUsed = time.Time{}
+		e.LastFailed = false
+		return
+	}
+	delete(s.entries, name)
+}
+
+// recentOrder returns entries with a recorded use, most recent first.
+func (s *Store) recentOrder() []*Entry {
+	var list []*Entry
+	for _, e := range s.entries {
+		if !e.LastUsed.IsZero() {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsed.After(list[j].LastUsed) })
+	if len(list) > MaxRecents {
+		list = list[:MaxRecents]
+	}
+	return list
+}
+
+// RecentRank returns name's position (0 = most recent) among the last
+// MaxRecents used servers, and whether it appears there at all.
+func (s *Store) RecentRank(name string) (int, bool) {
+	for i, e := range s.recentOrder() {
+		if e.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Favorites returns favorited server names.
+func (s *Store) Favorites() []string {
+	var names []string
+	for _, e := range s.entries {
+		if e.Favorite {
+			names = append(names, e.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Recents returns up to MaxRecents server names, most recent first.
+func (s *Store) Recents() []string {
+	order := s.recentOrder()
+	names := make([]string, len(order))
+	for i, e := range order {
+		names[i] = e.Name
+	}
+	return names
+}
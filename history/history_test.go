@@ -0,0 +1,93 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestToggleFavoriteAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.IsFavorite("web1") {
+		t.Fatal("web1 should not start as a favorite")
+	}
+	if fav := s.ToggleFavorite("web1"); !fav {
+		t.Fatal("ToggleFavorite should report true after favoriting")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.IsFavorite("web1") {
+		t.Fatal("favorite did not survive a save/load round trip")
+	}
+}
+
+func TestRecentRank(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now()
+	s.MarkUsed("old", base.Add(-time.Hour))
+	s.MarkUsed("new", base)
+
+	rank, ok := s.RecentRank("new")
+	if !ok || rank != 0 {
+		t.Fatalf("RecentRank(new) = %d, %v; want 0, true", rank, ok)
+	}
+	rank, ok = s.RecentRank("old")
+	if !ok || rank != 1 {
+		t.Fatalf("RecentRank(old) = %d, %v; want 1, true", rank, ok)
+	}
+	if _, ok := s.RecentRank("never-used"); ok {
+		t.Fatal("RecentRank should report false for a server never used")
+	}
+}
+
+func TestForget(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MarkUsed("web1", time.Now())
+	s.ToggleFavorite("web1")
+
+	s.Forget("web1")
+	if _, ok := s.RecentRank("web1"); ok {
+		t.Fatal("Forget should clear recency")
+	}
+	if !s.IsFavorite("web1") {
+		t.Fatal("Forget should not clear favorite status")
+	}
+
+	s.ToggleFavorite("web1") // unfavorite
+	s.MarkUsed("web1", time.Now())
+	s.Forget("web1")
+	if _, ok := s.entries["web1"]; ok {
+		t.Fatal("non-favorite entry should be gone entirely after Forget")
+	}
+}
+
+func TestMarkFailed(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MarkFailed("web1")
+	if !s.LastFailed("web1") {
+		t.Fatal("expected web1 to be marked as last-failed")
+	}
+	s.MarkUsed("web1", time.Now())
+	if s.LastFailed("web1") {
+		t.Fatal("MarkUsed should clear a previous failure")
+	}
+}
@@ -0,0 +1,72 @@
+// Package launcher abstracts how sshmenu actually starts a session for a
+// chosen server, so that behavior can vary (shell out vs. an in-process SSH
+// client) without the selection logic in main needing to know which.
+package launcher
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Target describes the session to start. Which fields matter depends on the
+// Launcher: the shell launcher only looks at Command, the native launcher
+// only looks at the connection fields.
+type Target struct {
+	Name string
+
+	// Command is a full shell command line, used by the shell launcher.
+	Command string
+
+	// Connection fields, used by the native launcher.
+	Host         string
+	Port         int
+	User         string
+	IdentityFile string
+	Jump         string
+	ForwardAgent bool
+}
+
+// Launcher starts an interactive session for a Target and blocks until it
+// ends.
+type Launcher interface {
+	Launch(target Target) error
+}
+
+// New returns the Launcher for the named backend: "shell" (the default,
+// shells out via bash -c) or "native" (connects over SSH in-process).
+func New(backend string) (Launcher, error) {
+	switch backend {
+	case "", "shell":
+		return ShellLauncher{}, nil
+	case "native":
+		return NativeLauncher{}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (want \"shell\" or \"native\")", backend)
+	}
+}
+
+// ParseSSHURL parses an "ssh://user@host:port" target into its connection
+// fields, so a native-backend server can be configured with a single URL
+// instead of separate host/port/user keys.
+func ParseSSHURL(raw string) (Target, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Target{}, fmt.Errorf("parsing %q: %w", raw, err)
+	}
+	if u.Scheme != "ssh" {
+		return Target{}, fmt.Errorf("%q is not an ssh:// URL", raw)
+	}
+	t := Target{Host: u.Hostname()}
+	if u.User != nil {
+		t.User = u.User.Username()
+	}
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return Target{}, fmt.Errorf("invalid port in %q: %w", raw, err)
+		}
+		t.Port = port
+	}
+	return t, nil
+}
@@ -0,0 +1,408 @@
+package launcher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+// NativeLauncher connects over SSH in-process using golang.org/x/crypto/ssh
+// instead of shelling out to a system ssh binary, so it works the same way
+// on Windows as it does anywhere else.
+type NativeLauncher struct {
+	// SSHConfigPath overrides ~/.ssh/config. Tests set this to point at a
+	// fixture file; zero value means the real user config.
+	SSHConfigPath string
+
+	// KnownHostsPath overrides ~/.ssh/known_hosts. Tests set this to point
+	// at a fixture file; zero value means the real user known_hosts.
+	KnownHostsPath string
+
+	// confirmHostKey is asked to accept a host key not yet present in
+	// known_hosts (trust-on-first-use). Tests substitute a function that
+	// doesn't block on stdin; zero value prompts interactively.
+	confirmHostKey func(hostname string, key ssh.PublicKey) bool
+
+	// dial lets tests substitute a fake SSH server instead of a real TCP
+	// connection.
+	dial func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error)
+}
+
+func (l NativeLauncher) Launch(target Target) error {
+	resolved, err := l.resolve(target)
+	if err != nil {
+		return err
+	}
+
+	hostKeyCallback, err := l.hostKeyCallback()
+	if err != nil {
+		return fmt.Errorf("setting up host key verification: %w", err)
+	}
+
+	clientConfig := &ssh.ClientConfig{
+		User:            resolved.user,
+		Auth:            resolved.authMethods(),
+		HostKeyCallback: hostKeyCallback,
+	}
+
+	dial := l.dial
+	if dial == nil {
+		dial = ssh.Dial
+	}
+
+	client, err := dialWithJump(dial, resolved, clientConfig)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", resolved.addr(), err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("opening session on %s: %w", resolved.addr(), err)
+	}
+	defer session.Close()
+
+	if resolved.forwardAgent {
+		if err := forwardAgent(client, session); err != nil {
+			return fmt.Errorf("forwarding ssh agent to %s: %w", resolved.addr(), err)
+		}
+	}
+
+	return runInteractive(session)
+}
+
+// hostConfig is a Target fully resolved against ~/.ssh/config.
+type hostConfig struct {
+	host         string
+	port         int
+	user         string
+	identityFile string
+	jump         string
+	forwardAgent bool
+}
+
+func (c hostConfig) addr() string {
+	return net.JoinHostPort(c.host, strconv.Itoa(c.port))
+}
+
+func (c hostConfig) authMethods() []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+	if key, err := loadIdentity(c.identityFile); err == nil {
+		methods = append(methods, ssh.PublicKeys(key))
+	}
+	if signers, err := agentSigners(); err == nil {
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			return signers, nil
+		}))
+	}
+	return methods
+}
+
+// resolve fills in anything target didn't specify from ~/.ssh/config:
+// IdentityFile, ProxyJump, and the default port/user.
+func (l NativeLauncher) resolve(target Target) (hostConfig, error) {
+	if target.Host == "" {
+		return hostConfig{}, fmt.Errorf("server %q has no host configured for the native backend", target.Name)
+	}
+	cfg := hostConfig{
+		host:         target.Host,
+		port:         target.Port,
+		user:         target.User,
+		identityFile: target.IdentityFile,
+		jump:         target.Jump,
+		forwardAgent: target.ForwardAgent,
+	}
+
+	sshCfg, err := l.loadSSHConfig()
+	if err != nil {
+		return hostConfig{}, err
+	}
+	if sshCfg != nil {
+		if cfg.user == "" {
+			cfg.user, _ = sshCfg.Get(cfg.host, "User")
+		}
+		if cfg.port == 0 {
+			if p, _ := sshCfg.Get(cfg.host, "Port"); p != "" {
+				if port, err := strconv.Atoi(p); err == nil {
+					cfg.port = port
+				}
+			}
+		}
+		if cfg.identityFile == "" {
+			if id, _ := sshCfg.Get(cfg.host, "IdentityFile"); id != "" {
+				cfg.identityFile = id
+			}
+		}
+		if cfg.jump == "" {
+			cfg.jump, _ = sshCfg.Get(cfg.host, "ProxyJump")
+		}
+		if !cfg.forwardAgent {
+			if fwd, _ := sshCfg.Get(cfg.host, "ForwardAgent"); strings.EqualFold(fwd, "yes") {
+				cfg.forwardAgent = true
+			}
+		}
+	}
+
+	if cfg.user == "" {
+		if u := os.Getenv("USER"); u != "" {
+			cfg.user = u
+		}
+	}
+	if cfg.port == 0 {
+		cfg.port = 22
+	}
+	cfg.identityFile = expandHome(cfg.identityFile)
+	return cfg, nil
+}
+
+func (l NativeLauncher) loadSSHConfig() (*ssh_config.Config, error) {
+	path := l.SSHConfigPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, ".ssh", "config")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+	cfg, err := ssh_config.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// hostKeyCallback builds an ssh.HostKeyCallback backed by known_hosts,
+// verifying both the direct target and (since dialWithJump reuses this same
+// callback) any ProxyJump hop against it. A host key not yet on record is
+// handled trust-on-first-use: the user is asked to confirm its fingerprint,
+// and an accepted key is appended to known_hosts so future connections
+// verify silently. A key that's on record but doesn't match - a possible
+// MITM - is always rejected outright, with no prompt.
+func (l NativeLauncher) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	path := l.KnownHostsPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("locating known_hosts: %w", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	if err := ensureKnownHostsFile(path); err != nil {
+		return nil, fmt.Errorf("preparing %s: %w", path, err)
+	}
+	check, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	confirm := l.confirmHostKey
+	if confirm == nil {
+		confirm = promptUnknownHostKey
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+		if !confirm(hostname, key) {
+			return fmt.Errorf("host key for %s rejected", hostname)
+		}
+		return appendKnownHost(path, hostname, key)
+	}, nil
+}
+
+// promptUnknownHostKey asks the user, on stdin/stdout, whether to trust a
+// host key not yet in known_hosts - the same prompt `ssh` itself shows on
+// first connection to a host.
+func promptUnknownHostKey(hostname string, key ssh.PublicKey) bool {
+	fmt.Fprintf(os.Stderr, "The authenticity of host %q can't be established.\n", hostname)
+	fmt.Fprintf(os.Stderr, "%s key fingerprint is %s.\n", key.Type(), ssh.FingerprintSHA256(key))
+	fmt.Fprint(os.Stderr, "Are you sure you want to continue connecting (yes/no)? ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "yes")
+}
+
+// ensureKnownHostsFile creates path (and its parent directory) if it
+// doesn't exist yet, since knownhosts.New refuses to open a missing file
+// even though "no known hosts yet" is the normal state for a fresh install.
+func ensureKnownHostsFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// appendKnownHost records an accepted host key, the way `ssh` does after a
+// trust-on-first-use prompt.
+func appendKnownHost(path, hostname string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{hostname}, key) + "\n")
+	return err
+}
+
+// dialWithJump connects to target, routing the TCP connection through a
+// ProxyJump host first when one is configured.
+func dialWithJump(dial func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error), target hostConfig, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if target.jump == "" {
+		return dial("tcp", target.addr(), config)
+	}
+
+	jumpHost, jumpUser := target.jump, target.user
+	if i := strings.Index(jumpHost, "@"); i >= 0 {
+		jumpUser, jumpHost = jumpHost[:i], jumpHost[i+1:]
+	}
+	jumpPort := "22"
+	if h, p, err := net.SplitHostPort(jumpHost); err == nil {
+		jumpHost, jumpPort = h, p
+	}
+
+	jumpConfig := &ssh.ClientConfig{
+		User:            jumpUser,
+		Auth:            config.Auth,
+		HostKeyCallback: config.HostKeyCallback,
+	}
+	jumpClient, err := dial("tcp", net.JoinHostPort(jumpHost, jumpPort), jumpConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to jump host %s: %w", jumpHost, err)
+	}
+
+	conn, err := jumpClient.Dial("tcp", target.addr())
+	if err != nil {
+		jumpClient.Close()
+		return nil, fmt.Errorf("dialing %s via jump host %s: %w", target.addr(), jumpHost, err)
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, target.addr(), config)
+	if err != nil {
+		jumpClient.Close()
+		return nil, err
+	}
+	return ssh.NewClient(ncc, chans, reqs), nil
+}
+
+func loadIdentity(path string) (ssh.Signer, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no identity file configured")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+func dialAgent() (agent.Agent, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+	return agent.NewClient(conn), nil
+}
+
+func agentSigners() ([]ssh.Signer, error) {
+	ag, err := dialAgent()
+	if err != nil {
+		return nil, err
+	}
+	return ag.Signers()
+}
+
+// forwardAgent requests agent forwarding on session and serves the local
+// ssh-agent over it, so commands run on the remote host (e.g. a further
+// hop through a ProxyJump) can authenticate using the same local keys.
+func forwardAgent(client *ssh.Client, session *ssh.Session) error {
+	ag, err := dialAgent()
+	if err != nil {
+		return err
+	}
+	if err := agent.RequestAgentForwarding(session); err != nil {
+		return err
+	}
+	return agent.ForwardToAgent(client, ag)
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// runInteractive puts the local terminal in raw mode, allocates a remote
+// PTY sized to match it, and proxies stdin/stdout/stderr until the session
+// ends.
+func runInteractive(session *ssh.Session) error {
+	fd := int(os.Stdin.Fd())
+	width, height := 80, 24
+	if term.IsTerminal(fd) {
+		if w, h, err := term.GetSize(fd); err == nil {
+			width, height = w, h
+		}
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("putting terminal in raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty("xterm-256color", height, width, modes); err != nil {
+		return fmt.Errorf("requesting pty: %w", err)
+	}
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	if err := session.Shell(); err != nil {
+		return fmt.Errorf("starting shell: %w", err)
+	}
+	return session.Wait()
+}
@@ -0,0 +1,19 @@
+package launcher
+
+import (
+	"os"
+	"os/exec"
+)
+
+// ShellLauncher runs target.Command through the system shell. This is the
+// original, default behavior: it works anywhere bash is installed but gives
+// sshmenu no control over the session beyond the exit code.
+type ShellLauncher struct{}
+
+func (ShellLauncher) Launch(target Target) error {
+	cmd := exec.Command("bash", "-c", target.Command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goplugin "plugin"
+)
+
+// LoadPlugins opens every .so file in dir and looks up its exported "Hook"
+// symbol (a value, or pointer to a value, implementing the Hook interface).
+// A dir that doesn't exist yields no plugins rather than an error, since
+// that's the state of a fresh install with no plugins configured.
+func LoadPlugins(dir string) ([]Hook, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var hooks []Hook
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".so" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		p, err := goplugin.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening plugin %s: %w", path, err)
+		}
+		sym, err := p.Lookup("Hook")
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: missing exported Hook symbol: %w", path, err)
+		}
+		hook, ok := sym.(Hook)
+		if !ok {
+			if hookPtr, ok := sym.(*Hook); ok {
+				hook = *hookPtr
+			} else {
+				return nil, fmt.Errorf("plugin %s: exported Hook does not implement plugin.Hook", path)
+			}
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LoadPlugins reports an error if dir contains any .so files, since Go's
+// plugin package doesn't support windows; shell hooks remain available
+// there. A dir with no .so files (including one that doesn't exist) yields
+// no plugins rather than an error.
+func LoadPlugins(dir string) ([]Hook, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".so" {
+			return nil, errors.New("plugin: .so hooks are not supported on windows")
+		}
+	}
+	return nil, nil
+}
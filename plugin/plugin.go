@@ -0,0 +1,80 @@
+// Package plugin lets operators run custom logic around each SSH session,
+// either as a compiled .so loaded from ~/.config/sshmenu/plugins/ or as a
+// shell command configured in sshmenu.yaml. Both forms implement Hook.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Server is the connection info visible to hooks. It's a subset of the
+// launcher.Target the session is actually run with, kept separate so this
+// package doesn't need to import launcher or main's config types.
+type Server struct {
+	Name    string
+	Host    string
+	Port    int
+	User    string
+	Command string
+}
+
+// Hook runs around a session. PreConnect runs before the launcher starts the
+// session; PostConnect runs after it ends, with the session's error (nil on
+// success).
+type Hook interface {
+	Name() string
+	PreConnect(ctx context.Context, server *Server) error
+	PostConnect(ctx context.Context, server *Server, exitErr error) error
+}
+
+// Registry holds the hooks to run for every session, each with its own
+// fail-open/fail-closed policy.
+type Registry struct {
+	hooks []registration
+}
+
+type registration struct {
+	hook     Hook
+	required bool
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds hook to the registry. When required is true, a failing
+// PreConnect aborts the session (fail closed); otherwise the failure is
+// logged to stderr and the session proceeds (fail open). PostConnect
+// failures are always logged rather than abortable, since the session has
+// already finished by then.
+func (r *Registry) Register(hook Hook, required bool) {
+	r.hooks = append(r.hooks, registration{hook: hook, required: required})
+}
+
+// PreConnect runs every registered hook's PreConnect in registration order,
+// stopping at the first required hook that fails.
+func (r *Registry) PreConnect(ctx context.Context, server *Server) error {
+	for _, reg := range r.hooks {
+		if err := reg.hook.PreConnect(ctx, server); err != nil {
+			if reg.required {
+				return fmt.Errorf("hook %s: %w", reg.hook.Name(), err)
+			}
+			fmt.Fprintf(os.Stderr, "warning: hook %s failed (continuing): %v\n", reg.hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+// PostConnect runs every registered hook's PostConnect in registration
+// order. Failures are logged but never abort, since the session is already
+// over.
+func (r *Registry) PostConnect(ctx context.Context, server *Server, exitErr error) {
+	for _, reg := range r.hooks {
+		if err := reg.hook.PostConnect(ctx, server, exitErr); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: hook %s failed: %v\n", reg.hook.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+type fakeHook struct {
+	name      string
+	preErr    error
+	postErr   error
+	preCalls  int
+	postCalls int
+}
+
+func (h *fakeHook) Name() string { return h.name }
+
+func (h *fakeHook) PreConnect(ctx context.Context, server *Server) error {
+	h.preCalls++
+	return h.preErr
+}
+
+func (h *fakeHook) PostConnect(ctx context.Context, server *Server, exitErr error) error {
+	h.postCalls++
+	return h.postErr
+}
+
+func TestRegistry_RequiredHookAbortsPreConnect(t *testing.T) {
+	r := NewRegistry()
+	failing := &fakeHook{name: "failing", preErr: errors.New("boom")}
+	after := &fakeHook{name: "after"}
+	r.Register(failing, true)
+	r.Register(after, true)
+
+	if err := r.PreConnect(context.Background(), &Server{Name: "web1"}); err == nil {
+		t.Fatal("expected PreConnect to return an error")
+	}
+	if after.preCalls != 0 {
+		t.Fatal("hook after a required failure should not run")
+	}
+}
+
+func TestRegistry_OptionalHookFailsOpen(t *testing.T) {
+	r := NewRegistry()
+	failing := &fakeHook{name: "failing", preErr: errors.New("boom")}
+	after := &fakeHook{name: "after"}
+	r.Register(failing, false)
+	r.Register(after, true)
+
+	if err := r.PreConnect(context.Background(), &Server{Name: "web1"}); err != nil {
+		t.Fatalf("optional hook failure should not abort: %v", err)
+	}
+	if after.preCalls != 1 {
+		t.Fatal("hook after an optional failure should still run")
+	}
+}
+
+func TestRegistry_PostConnectNeverAborts(t *testing.T) {
+	r := NewRegistry()
+	failing := &fakeHook{name: "failing", postErr: errors.New("boom")}
+	after := &fakeHook{name: "after"}
+	r.Register(failing, true)
+	r.Register(after, true)
+
+	r.PostConnect(context.Background(), &Server{Name: "web1"}, nil)
+	if after.postCalls != 1 {
+		t.Fatal("a failing required hook should not stop later PostConnect calls")
+	}
+}
+
+func TestShellHook_Templates(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "out.txt")
+
+	h := NewShellPostHook("echo {server} {exit} > " + outFile)
+	if err := h.PostConnect(context.Background(), &Server{Name: "web1"}, errors.New("fail")); err != nil {
+		t.Fatalf("PostConnect: %v", err)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "web1 1\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestShellHook_PreOnlyIgnoresPostConnect(t *testing.T) {
+	h := NewShellPreHook("true {server}")
+	if err := h.PostConnect(context.Background(), &Server{Name: "web1"}, nil); err != nil {
+		t.Fatalf("a pre-only hook's PostConnect should be a no-op: %v", err)
+	}
+}
+
+func TestLoadPlugins_MissingDirYieldsNoPlugins(t *testing.T) {
+	hooks, err := LoadPlugins(filepath.Join(t.TempDir(), "does-not-exist"))
+	if err != nil {
+		t.Fatalf("missing plugin dir should not error: %v", err)
+	}
+	if len(hooks) != 0 {
+		t.Fatalf("expected no hooks, got %d", len(hooks))
+	}
+}
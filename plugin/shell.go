@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// ShellHook runs a templated shell command as a pre- or post-connect hook,
+// the YAML-configured alternative to a compiled .so plugin. Templates use
+// {server} and, for post-hooks, {exit}, the same way replaceServer handles
+// {server} for the launch command.
+type ShellHook struct {
+	HookName     string
+	PreTemplate  string // empty if this is a post-only hook
+	PostTemplate string // empty if this is a pre-only hook
+}
+
+// NewShellPreHook returns a ShellHook that runs template before connecting.
+func NewShellPreHook(template string) *ShellHook {
+	return &ShellHook{HookName: "shell pre-hook: " + template, PreTemplate: template}
+}
+
+// NewShellPostHook returns a ShellHook that runs template after the session
+// ends.
+func NewShellPostHook(template string) *ShellHook {
+	return &ShellHook{HookName: "shell post-hook: " + template, PostTemplate: template}
+}
+
+func (h *ShellHook) Name() string { return h.HookName }
+
+func (h *ShellHook) PreConnect(ctx context.Context, server *Server) error {
+	if h.PreTemplate == "" {
+		return nil
+	}
+	return runShellTemplate(ctx, h.PreTemplate, server, nil)
+}
+
+func (h *ShellHook) PostConnect(ctx context.Context, server *Server, exitErr error) error {
+	if h.PostTemplate == "" {
+		return nil
+	}
+	return runShellTemplate(ctx, h.PostTemplate, server, exitErr)
+}
+
+func runShellTemplate(ctx context.Context, template string, server *Server, exitErr error) error {
+	cmd := strings.ReplaceAll(template, "{server}", server.Name)
+	cmd = strings.ReplaceAll(cmd, "{exit}", exitCode(exitErr))
+	return exec.CommandContext(ctx, "bash", "-c", cmd).Run()
+}
+
+// exitCode renders a session's outcome the way a shell post-hook expects:
+// "0" on success, "1" on failure.
+func exitCode(exitErr error) string {
+	if exitErr == nil {
+		return "0"
+	}
+	return "1"
+}
@@ -0,0 +1,177 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// PickAction is what the user did in a Picker.Run call.
+type PickAction int
+
+const (
+	// PickSelected means the user pressed Enter on an item (Name is set).
+	PickSelected PickAction = iota
+	// PickQuit means the user backed out (q, Esc, Ctrl-C, or an empty list).
+	PickQuit
+)
+
+const (
+	keyUp     rune = -1
+	keyDown   rune = -2
+	keyEnter  rune = '\r'
+	keyEscape rune = 27
+	keyCtrlC  rune = 3
+)
+
+// Picker renders a ranked list and lets the user navigate it, select an
+// entry, toggle its favorite flag, or forget it from history, all without
+// leaving the list. promptui.Select has no hook for the latter two, so the
+// flat search mode drives its own small raw-terminal loop instead.
+type Picker struct {
+	Results []Result
+
+	// OnToggleFavorite is called with the highlighted item's name on 'f'.
+	// It should mutate the backing store and return the re-ranked results.
+	OnToggleFavorite func(name string) []Result
+	// OnForget is called on 'd', analogous to OnToggleFavorite.
+	OnForget func(name string) []Result
+
+	In  io.Reader
+	Out io.Writer
+}
+
+// Run drives the picker until the user selects an item or quits. Stdin must
+// be a terminal; callers should check term.IsTerminal first.
+func (p *Picker) Run() (name string, action PickAction, err error) {
+	in, out := p.In, p.Out
+	useRealTerminal := in == nil
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if useRealTerminal {
+		fd := int(os.Stdin.Fd())
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return "", PickQuit, err
+		}
+		defer term.Restore(fd, state)
+	}
+
+	cursor := 0
+	reader := bufio.NewReader(in)
+	for {
+		if len(p.Results) == 0 {
+			return "", PickQuit, nil
+		}
+		p.draw(out, cursor)
+
+		key, err := readKey(reader)
+		if err != nil {
+			return "", PickQuit, err
+		}
+		switch key {
+		case keyUp:
+			if cursor > 0 {
+				cursor--
+			}
+		case keyDown:
+			if cursor < len(p.Results)-1 {
+				cursor++
+			}
+		case keyEnter:
+			return p.Results[cursor].Name, PickSelected, nil
+		case 'f':
+			if p.OnToggleFavorite != nil {
+				name := p.Results[cursor].Name
+				p.Results = p.OnToggleFavorite(name)
+				cursor = clampIndex(indexOf(p.Results, name), len(p.Results))
+			}
+		case 'd':
+			if p.OnForget != nil {
+				name := p.Results[cursor].Name
+				p.Results = p.OnForget(name)
+				cursor = clampIndex(cursor, len(p.Results))
+			}
+		case 'q', keyEscape, keyCtrlC:
+			return "", PickQuit, nil
+		}
+	}
+}
+
+func (p *Picker) draw(out io.Writer, cursor int) {
+	fmt.Fprint(out, "\033[2J\033[H")
+	fmt.Fprint(out, "Select Server  (↑/↓ move, Enter select, f favorite, d forget, q quit)\r\n")
+	for i, r := range p.Results {
+		prefix := "  "
+		if i == cursor {
+			prefix = "> "
+		}
+		fmt.Fprintf(out, "%s%s\r\n", prefix, r.Label)
+	}
+}
+
+func indexOf(results []Result, name string) int {
+	for i, r := range results {
+		if r.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func clampIndex(i, n int) int {
+	if n == 0 {
+		return 0
+	}
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// readKey reads one logical keypress: a printable rune, or one of the
+// keyUp/keyDown/keyEnter/keyEscape/keyCtrlC sentinels.
+func readKey(r *bufio.Reader) (rune, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 3:
+		return keyCtrlC, nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case 27:
+		b2, err := r.ReadByte()
+		if err != nil {
+			return keyEscape, nil
+		}
+		if b2 != '[' {
+			return keyEscape, nil
+		}
+		b3, err := r.ReadByte()
+		if err != nil {
+			return keyEscape, nil
+		}
+		switch b3 {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		}
+		return keyEscape, nil
+	default:
+		return rune(b), nil
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProbeTimeout bounds how long a single reachability probe may block.
+const ProbeTimeout = 300 * time.Millisecond
+
+// Prober checks whether host:port accepts TCP connections, caching each
+// result for the lifetime of the Prober (one per program run, so repeated
+// searches don't re-probe the same server).
+type Prober struct {
+	mu     sync.Mutex
+	cached map[string]bool
+}
+
+// NewProber returns a ready-to-use Prober.
+func NewProber() *Prober {
+	return &Prober{cached: map[string]bool{}}
+}
+
+// Target identifies a host:port pair to probe.
+type Target struct {
+	Host string
+	Port int
+}
+
+// ProbeAll probes every target concurrently and blocks until all of them
+// have a cached result, so a batch of N servers costs one ProbeTimeout
+// instead of N. Individual results are retrieved afterward via Probe, which
+// then hits the cache.
+func (p *Prober) ProbeAll(targets []Target) {
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			p.Probe(t.Host, t.Port)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// Probe reports whether host:port is reachable. Once an address has a
+// cached result it is not probed again for this Prober's lifetime;
+// concurrent first calls for the same address may each dial it.
+func (p *Prober) Probe(host string, port int) bool {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	p.mu.Lock()
+	if reachable, ok := p.cached[addr]; ok {
+		p.mu.Unlock()
+		return reachable
+	}
+	p.mu.Unlock()
+
+	conn, err := net.DialTimeout("tcp", addr, ProbeTimeout)
+	reachable := err == nil
+	if conn != nil {
+		conn.Close()
+	}
+
+	p.mu.Lock()
+	p.cached[addr] = reachable
+	p.mu.Unlock()
+	return reachable
+}
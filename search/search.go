@@ -0,0 +1,101 @@
+// Package search fuzzy-matches and ranks servers for sshmenu's flat search
+// mode, decorating each match with status glyphs for favorite/recent/
+// reachable/failed state.
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// Item is one candidate to match and rank. The Favorite/Recent/Reachable/
+// LastFailed fields are computed by the caller (from a history.Store and a
+// reachability probe) before ranking.
+type Item struct {
+	Name        string
+	Description string
+	Tags        []string
+
+	Favorite   bool
+	Recent     bool
+	RecentRank int
+	Reachable  bool
+	ReachKnown bool
+	LastFailed bool
+}
+
+// Result is an Item plus its fuzzy score and decorated display label.
+type Result struct {
+	Item
+	Score int
+	Label string
+}
+
+// Rank matches query against each item's name, description, and tags, then
+// sorts the survivors by (favorite desc, recent-rank asc, fuzzy score
+// desc). An empty query matches everything, in the same order.
+func Rank(items []Item, query string) []Result {
+	if strings.TrimSpace(query) == "" {
+		results := make([]Result, len(items))
+		for i, it := range items {
+			results[i] = Result{Item: it, Label: Label(it)}
+		}
+		sortResults(results)
+		return results
+	}
+
+	haystacks := make([]string, len(items))
+	for i, it := range items {
+		haystacks[i] = it.Name + " " + it.Description + " " + strings.Join(it.Tags, " ")
+	}
+	matches := fuzzy.Find(query, haystacks)
+
+	results := make([]Result, 0, len(matches))
+	for _, m := range matches {
+		it := items[m.Index]
+		results = append(results, Result{Item: it, Score: m.Score, Label: Label(it)})
+	}
+	sortResults(results)
+	return results
+}
+
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Favorite != b.Favorite {
+			return a.Favorite
+		}
+		if a.Recent != b.Recent {
+			return a.Recent
+		}
+		if a.Recent && b.Recent && a.RecentRank != b.RecentRank {
+			return a.RecentRank < b.RecentRank
+		}
+		return a.Score > b.Score
+	})
+}
+
+// Label renders it as "[glyphs] name - description", with one glyph per
+// status: [*] favorite, [r] recently used, [up-arrow] reachable, [!] last
+// connection failed.
+func Label(it Item) string {
+	var glyphs strings.Builder
+	if it.Favorite {
+		glyphs.WriteString("[*]")
+	}
+	if it.Recent {
+		glyphs.WriteString("[r]")
+	}
+	if it.ReachKnown && it.Reachable {
+		glyphs.WriteString("[↑]")
+	}
+	if it.LastFailed {
+		glyphs.WriteString("[!]")
+	}
+	if glyphs.Len() == 0 {
+		return it.Name + " - " + it.Description
+	}
+	return glyphs.String() + " " + it.Name + " - " + it.Description
+}
@@ -0,0 +1,135 @@
+package search
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRank_FavoriteBeatsScore(t *testing.T) {
+	items := []Item{
+		{Name: "web1", Description: "production web server"},
+		{Name: "web2", Description: "production web server", Favorite: true},
+	}
+	results := Rank(items, "web")
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Name != "web2" {
+		t.Fatalf("favorite should sort first, got order %v", names(results))
+	}
+}
+
+func TestRank_RecentBeforeScore(t *testing.T) {
+	items := []Item{
+		{Name: "a", Description: "alpha box"},
+		{Name: "b", Description: "alpha box", Recent: true, RecentRank: 0},
+	}
+	results := Rank(items, "alpha")
+	if results[0].Name != "b" {
+		t.Fatalf("recent item should sort first, got order %v", names(results))
+	}
+}
+
+func TestRank_EmptyQueryReturnsAll(t *testing.T) {
+	items := []Item{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	results := Rank(items, "")
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+}
+
+func TestRank_NoMatch(t *testing.T) {
+	items := []Item{{Name: "web1", Description: "prod"}}
+	results := Rank(items, "zzzzz-no-match")
+	if len(results) != 0 {
+		t.Fatalf("expected no matches, got %v", names(results))
+	}
+}
+
+func TestLabel_Glyphs(t *testing.T) {
+	it := Item{Name: "db1", Description: "primary db", Favorite: true, Recent: true, ReachKnown: true, Reachable: true, LastFailed: true}
+	label := Label(it)
+	for _, want := range []string{"[*]", "[r]", "[↑]", "[!]", "db1 - primary db"} {
+		if !strings.Contains(label, want) {
+			t.Errorf("label %q missing %q", label, want)
+		}
+	}
+}
+
+func TestLabel_NoStatus(t *testing.T) {
+	it := Item{Name: "db1", Description: "primary db"}
+	if got, want := Label(it), "db1 - primary db"; got != want {
+		t.Errorf("Label() = %q, want %q", got, want)
+	}
+}
+
+func names(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Name
+	}
+	return out
+}
+
+func TestPicker_SelectWithFavoriteToggle(t *testing.T) {
+	results := []Result{
+		{Item: Item{Name: "a"}, Label: "a"},
+		{Item: Item{Name: "b"}, Label: "b"},
+	}
+	var toggled string
+	p := &Picker{
+		Results: results,
+		OnToggleFavorite: func(name string) []Result {
+			toggled = name
+			return results
+		},
+		In:  strings.NewReader("f\r"),
+		Out: &bytes.Buffer{},
+	}
+	name, action, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toggled != "a" {
+		t.Fatalf("toggled = %q, want a", toggled)
+	}
+	if action != PickSelected || name != "a" {
+		t.Fatalf("got (%q, %v), want (a, PickSelected)", name, action)
+	}
+}
+
+func TestPicker_NavigateAndSelect(t *testing.T) {
+	results := []Result{
+		{Item: Item{Name: "a"}, Label: "a"},
+		{Item: Item{Name: "b"}, Label: "b"},
+	}
+	p := &Picker{
+		Results: results,
+		In:      strings.NewReader("\x1b[B\r"), // down arrow, enter
+		Out:     &bytes.Buffer{},
+	}
+	name, action, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != PickSelected || name != "b" {
+		t.Fatalf("got (%q, %v), want (b, PickSelected)", name, action)
+	}
+}
+
+func TestPicker_Quit(t *testing.T) {
+	results := []Result{{Item: Item{Name: "a"}, Label: "a"}}
+	p := &Picker{
+		Results: results,
+		In:      strings.NewReader("q"),
+		Out:     &bytes.Buffer{},
+	}
+	_, action, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != PickQuit {
+		t.Fatalf("got %v, want PickQuit", action)
+	}
+}
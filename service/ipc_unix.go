@@ -0,0 +1,30 @@
+//go:build !windows
+
+package service
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// defaultAddr returns the Unix socket the agent listens on by default.
+func defaultAddr() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "sshmenu", "agent.sock"), nil
+}
+
+func listenIPC(addr string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(addr), 0o755); err != nil {
+		return nil, err
+	}
+	os.Remove(addr) // clear a stale socket left by an unclean shutdown
+	return net.Listen("unix", addr)
+}
+
+func dialIPC(addr string) (net.Conn, error) {
+	return net.Dial("unix", addr)
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package service
+
+import (
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// defaultAddr returns the named pipe the agent listens on by default, the
+// Windows equivalent of the Unix socket used elsewhere.
+func defaultAddr() (string, error) {
+	return `\\.\pipe\sshmenu-agent`, nil
+}
+
+func listenIPC(addr string) (net.Listener, error) {
+	return winio.ListenPipe(addr, nil)
+}
+
+func dialIPC(addr string) (net.Conn, error) {
+	return winio.DialPipe(addr, nil)
+}
@@ -0,0 +1,240 @@
+// Package service runs sshmenu as a persistent user-level background agent.
+// The agent, managed through github.com/kardianos/service (launchd, systemd
+// user units, or the Windows SCM), listens on a local socket and spawns a
+// terminal running the interactive menu each time sshmenu-trigger pings it.
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	svc "github.com/kardianos/service"
+)
+
+// Name identifies the agent with the OS service manager.
+const Name = "sshmenu-agent"
+
+// Config configures the resident agent.
+type Config struct {
+	// TerminalCommand overrides how a new terminal is spawned to host the
+	// menu; {command} is replaced with the sshmenu invocation to run inside
+	// it. Empty uses a per-OS default.
+	TerminalCommand string
+}
+
+// program implements service.Interface for the resident agent.
+type program struct {
+	cfg      Config
+	menuArgs []string
+	listener net.Listener
+	done     chan struct{}
+}
+
+func newService(cfg Config) (svc.Service, *program, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &program{
+		cfg:      cfg,
+		menuArgs: []string{exe},
+		done:     make(chan struct{}),
+	}
+	svcConfig := &svc.Config{
+		Name:        Name,
+		DisplayName: "SSHMenu Agent",
+		Description: "Resident sshmenu agent that pops up the server menu on trigger.",
+		Arguments:   []string{"--agent"},
+	}
+	s, err := svc.New(p, svcConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, p, nil
+}
+
+// Install registers sshmenu as a user-level background agent.
+func Install(cfg Config) error {
+	s, _, err := newService(cfg)
+	if err != nil {
+		return err
+	}
+	return s.Install()
+}
+
+// Uninstall removes the agent registered by Install.
+func Uninstall(cfg Config) error {
+	s, _, err := newService(cfg)
+	if err != nil {
+		return err
+	}
+	return s.Uninstall()
+}
+
+// Status reports the agent's current service-manager status.
+func Status(cfg Config) (string, error) {
+	s, _, err := newService(cfg)
+	if err != nil {
+		return "", err
+	}
+	st, err := s.Status()
+	if err != nil {
+		return "", err
+	}
+	switch st {
+	case svc.StatusRunning:
+		return "running", nil
+	case svc.StatusStopped:
+		return "stopped", nil
+	default:
+		return "unknown", nil
+	}
+}
+
+// Run starts the resident agent and blocks until the service manager (or an
+// interactive Ctrl-C) stops it. It's what sshmenu --agent calls into.
+func Run(cfg Config) error {
+	s, _, err := newService(cfg)
+	if err != nil {
+		return err
+	}
+	return s.Run()
+}
+
+// Addr returns the local IPC address the agent listens on and
+// sshmenu-trigger connects to: a Unix socket path, or a Windows named pipe
+// name.
+func Addr() (string, error) {
+	return defaultAddr()
+}
+
+// Trigger asks a running agent to pop up the menu. It's what the
+// sshmenu-trigger companion command does, and is wire-compatible with any
+// hotkey tool (sxhkd, Hammerspoon, PowerToys) that can run a command.
+func Trigger() error {
+	addr, err := Addr()
+	if err != nil {
+		return err
+	}
+	conn, err := dialIPC(addr)
+	if err != nil {
+		return fmt.Errorf("connecting to sshmenu agent: %w", err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("trigger\n"))
+	return err
+}
+
+func (p *program) Start(s svc.Service) error {
+	addr, err := Addr()
+	if err != nil {
+		return err
+	}
+	ln, err := listenIPC(addr)
+	if err != nil {
+		return err
+	}
+	p.listener = ln
+	go p.serve()
+	return nil
+}
+
+func (p *program) Stop(s svc.Service) error {
+	close(p.done)
+	return p.listener.Close()
+}
+
+func (p *program) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			select {
+			case <-p.done:
+				return
+			default:
+				continue
+			}
+		}
+		conn.Close()
+		if err := p.openTerminal(); err != nil {
+			fmt.Fprintln(os.Stderr, "sshmenu-agent: opening terminal:", err)
+		}
+	}
+}
+
+// defaultTerminalCommand returns the per-OS command line used to open a new
+// terminal running {command}, used when sshmenu.yaml sets no
+// terminal_command.
+func defaultTerminalCommand() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open -a Terminal {command}"
+	case "windows":
+		return "wt.exe {command}"
+	default:
+		return "x-terminal-emulator -e {command}"
+	}
+}
+
+func (p *program) openTerminal() error {
+	tmpl := p.cfg.TerminalCommand
+	if tmpl == "" {
+		tmpl = defaultTerminalCommand()
+	}
+
+	var args []string
+	for _, tok := range tokenize(tmpl) {
+		if tok == "{command}" {
+			args = append(args, p.menuArgs...)
+		} else {
+			args = append(args, tok)
+		}
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("terminal_command %q has no program to run", tmpl)
+	}
+
+	return exec.Command(args[0], args[1:]...).Start()
+}
+
+// tokenize splits a terminal_command template into argv the way a shell
+// would, without invoking one: whitespace-separated, with single or double
+// quotes around a word (e.g. an exe path containing spaces) taken
+// literally. There's no bash on Windows to lean on here, so sshmenu has to
+// do its own (minimal) word-splitting.
+func tokenize(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	var quote rune
+	has := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			has = true
+		case r == ' ' || r == '\t':
+			if has || cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				has = false
+			}
+		default:
+			cur.WriteRune(r)
+			has = true
+		}
+	}
+	if has || cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIPC_ListenAndTrigger(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := listenIPC(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	conn, err := dialIPC(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("trigger\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case line := <-received:
+		if strings.TrimSpace(line) != "trigger" {
+			t.Fatalf("got %q, want trigger", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("agent never received the trigger")
+	}
+}
+
+func TestDefaultTerminalCommand_HasPlaceholder(t *testing.T) {
+	if !strings.Contains(defaultTerminalCommand(), "{command}") {
+		t.Fatalf("default terminal command %q must template {command}", defaultTerminalCommand())
+	}
+}
+
+func TestOpenTerminal_RunsProgramDirectly(t *testing.T) {
+	touch, err := exec.LookPath("touch")
+	if err != nil {
+		t.Skip("touch not available")
+	}
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "out.txt")
+	p := &program{
+		// {command} expands to menuArgs verbatim, as separate argv
+		// entries handed straight to exec.Command - no shell involved,
+		// so this must work the same on Windows (no bash) as on Unix.
+		cfg:      Config{TerminalCommand: touch + " {command}"},
+		menuArgs: []string{outFile},
+	}
+	if err := p.openTerminal(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(outFile); err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("terminal command never ran")
+}
+
+func TestTokenize_QuotedWordWithSpaces(t *testing.T) {
+	got := tokenize(`"/Applications/My Terminal.app/bin" {command} --flag`)
+	want := []string{"/Applications/My Terminal.app/bin", "{command}", "--flag"}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize() = %#v, want %#v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tokenize()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -1,26 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"crypto/md5"
 	"github.com/manifoldco/promptui"
+	"github.com/vorn003/SSHMenu/history"
+	"github.com/vorn003/SSHMenu/launcher"
+	"github.com/vorn003/SSHMenu/plugin"
+	"github.com/vorn003/SSHMenu/search"
+	"github.com/vorn003/SSHMenu/service"
+	"github.com/vorn003/SSHMenu/updater"
 	"gopkg.in/yaml.v3"
 )
 
 // Version is set at build time using -ldflags
 var Version = "dev"
 
-// httpGet wraps http.Get for update functionality
-func httpGet(url string) (*http.Response, error) {
-	return http.Get(url)
-}
-
 // discardWriteCloser wraps io.Discard to satisfy io.WriteCloser
 type discardWriteCloser struct{}
 
@@ -67,6 +68,20 @@ type Server struct {
 	Name        string `yaml:"name"`
 	Description string `yaml:"description"`
 	Command     string `yaml:"command,omitempty"`
+
+	// Structured connection fields, used by the native backend instead of
+	// Command. Host is required for native mode; the rest are optional and
+	// fall back to ~/.ssh/config.
+	Host         string `yaml:"host,omitempty"`
+	Port         int    `yaml:"port,omitempty"`
+	User         string `yaml:"user,omitempty"`
+	IdentityFile string `yaml:"identity_file,omitempty"`
+	Jump         string `yaml:"jump,omitempty"`
+	ForwardAgent bool   `yaml:"forward_agent,omitempty"`
+
+	// Tags are free-form keywords matched by the flat search mode, in
+	// addition to Name and Description.
+	Tags []string `yaml:"tags,omitempty"`
 }
 
 type Project struct {
@@ -75,8 +90,72 @@ type Project struct {
 }
 
 type Config struct {
-	GlobalCommand string    `yaml:"global_command"`
-	Projects      []Project `yaml:"projects"`
+	GlobalCommand   string    `yaml:"global_command"`
+	Backend         string    `yaml:"backend,omitempty"`
+	Projects        []Project `yaml:"projects"`
+	Hooks           Hooks     `yaml:"hooks,omitempty"`
+	TerminalCommand string    `yaml:"terminal_command,omitempty"`
+}
+
+// HookCommand is one shell command run as a pre- or post-connect hook.
+// Command is templated the same way GlobalCommand is: {server} is replaced
+// with the server's name, and post-hooks also get {exit} (0 or 1). When
+// Required is true, a failing pre-connect hook aborts the session instead
+// of just logging a warning.
+type HookCommand struct {
+	Command  string `yaml:"command"`
+	Required bool   `yaml:"required,omitempty"`
+}
+
+// Hooks configures the shell-hook form of the plugin package's extension
+// point; see the plugin package for the compiled .so form.
+type Hooks struct {
+	Pre  []HookCommand `yaml:"pre,omitempty"`
+	Post []HookCommand `yaml:"post,omitempty"`
+}
+
+// resolveHostPortUser normalizes a Server's connection fields, expanding
+// Host when it's given as an "ssh://user@host:port" URL (see
+// launcher.ParseSSHURL) instead of a plain hostname. In the URL form, port
+// and user fall back to whatever the URL specifies when the server doesn't
+// set them directly. A malformed ssh:// URL is returned unchanged, so
+// callers can treat it like any other unreachable/unconnectable host.
+func resolveHostPortUser(host string, port int, user string) (string, int, string) {
+	if !strings.HasPrefix(host, "ssh://") {
+		return host, port, user
+	}
+	parsed, err := launcher.ParseSSHURL(host)
+	if err != nil {
+		return host, port, user
+	}
+	if port == 0 {
+		port = parsed.Port
+	}
+	if user == "" {
+		user = parsed.User
+	}
+	return parsed.Host, port, user
+}
+
+// launcherTarget builds the launcher.Target sshmenu hands off to run a
+// server's session, merging in cfg.GlobalCommand when the server has no
+// command of its own.
+func launcherTarget(cfg *Config, server Server) launcher.Target {
+	cmdStr := server.Command
+	if cmdStr == "" {
+		cmdStr = replaceServer(cfg.GlobalCommand, server.Name)
+	}
+	host, port, user := resolveHostPortUser(server.Host, server.Port, server.User)
+	return launcher.Target{
+		Name:         server.Name,
+		Command:      cmdStr,
+		Host:         host,
+		Port:         port,
+		User:         user,
+		IdentityFile: server.IdentityFile,
+		Jump:         server.Jump,
+		ForwardAgent: server.ForwardAgent,
+	}
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -99,16 +178,44 @@ func main() {
 
 	// Handle --help, --update, --version
 	args := os.Args[1:]
+
+	// Pull --backend=shell|native and --no-hooks out of args wherever they
+	// appear; neither is part of the search string.
+	cliBackend := ""
+	noHooks := false
+	filteredArgs := args[:0]
+	for _, a := range args {
+		switch {
+		case strings.HasPrefix(a, "--backend="):
+			cliBackend = strings.TrimPrefix(a, "--backend=")
+		case a == "--no-hooks":
+			noHooks = true
+		default:
+			filteredArgs = append(filteredArgs, a)
+		}
+	}
+	args = filteredArgs
+
 	if len(args) > 0 {
 		if args[0] == "--help" {
 			fmt.Printf(`SSHMenu - Interactive SSH launcher
-			
+
 Version: %s
 Usage:
-	sshmenu [search]
-	sshmenu --help         Show this help message
-	sshmenu --update       Update to latest release from GitHub
-	sshmenu --version      Show version
+	sshmenu [search] [--backend=shell|native] [--no-hooks]
+	sshmenu --help                    Show this help message
+	sshmenu --update [--dry-run] [--channel=beta] [--quiet] [--timeout=30s]
+	                                   Update to latest release from GitHub
+	sshmenu --history [forget <name>] List recent connections, or forget one
+	sshmenu --favorites [add|remove <name>]
+	                                   List favorites, or add/remove one
+	sshmenu --install-service         Register sshmenu as a background agent
+	sshmenu --uninstall-service       Remove the background agent
+	sshmenu --service-status          Show the background agent's status
+	sshmenu --version                 Show version
+
+The background agent listens for sshmenu-trigger, a companion command meant
+to be bound to a global hotkey (sxhkd, Hammerspoon, PowerToys, ...).
 `, Version)
 			os.Exit(0)
 		}
@@ -116,86 +223,36 @@ Usage:
 			fmt.Println(Version)
 			os.Exit(0)
 		}
+		if args[0] == "--history" {
+			runHistoryCommand(args[1:])
+		}
+		if args[0] == "--favorites" {
+			runFavoritesCommand(args[1:])
+		}
 		if args[0] == "--update" {
-			// Download latest release from GitHub and replace current binary
-			updateURL := "https://github.com/vorn003/SSHMenu/releases/latest/download/sshmenu_linux_amd64"
-			exePath, err := os.Executable()
-			if err != nil {
-				fmt.Println("Error determining executable path:", err)
-				os.Exit(1)
-			}
-			// Download to a temporary file in the same directory as the executable
-			exeDir := exePath
-			if idx := strings.LastIndex(exePath, string(os.PathSeparator)); idx != -1 {
-				exeDir = exePath[:idx]
-			}
-			tmpFile := exeDir + string(os.PathSeparator) + ".sshmenu_update_tmp"
-			fmt.Println("Downloading latest release...")
-			resp, err := httpGet(updateURL)
-			if err != nil {
-				fmt.Println("Download failed:", err)
-				os.Exit(1)
-			}
-			defer resp.Body.Close()
-			ct := resp.Header.Get("Content-Type")
-			if strings.Contains(ct, "text/html") {
-				fmt.Println("Error: Downloaded file is HTML, not a binary. Check the release URL or authentication.")
-				os.Exit(2)
-			}
-			out, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
-			if err != nil {
-				fmt.Println("Error creating temporary file for update:", err)
-				os.Exit(1)
-			}
-			defer out.Close()
-			_, err = io.Copy(out, resp.Body)
-			if err != nil {
-				fmt.Println("Error writing update:", err)
-				os.Exit(1)
-			}
-			// Compare md5sum of tempfile and current binary
-			md5sum := func(path string) (string, error) {
-				f, err := os.Open(path)
-				if err != nil {
-					return "", err
-				}
-				defer f.Close()
-				h := md5.New()
-				if _, err := io.Copy(h, f); err != nil {
-					return "", err
+			opts := updater.Options{}
+			for _, a := range args[1:] {
+				switch {
+				case a == "--dry-run":
+					opts.DryRun = true
+				case a == "--quiet":
+					opts.Quiet = true
+				case strings.HasPrefix(a, "--channel="):
+					opts.Channel = strings.TrimPrefix(a, "--channel=")
+				case strings.HasPrefix(a, "--timeout="):
+					d, err := time.ParseDuration(strings.TrimPrefix(a, "--timeout="))
+					if err != nil {
+						fmt.Println("Invalid --timeout:", err)
+						os.Exit(1)
+					}
+					opts.Timeout = d
 				}
-				return fmt.Sprintf("%x", h.Sum(nil)), nil
-			}
-			tmpSum, err := md5sum(tmpFile)
-			if err != nil {
-				fmt.Println("Error computing md5sum for tempfile:", err)
-				os.Exit(1)
 			}
-			exeSum, err := md5sum(exePath)
-			if err != nil {
-				fmt.Println("Error computing md5sum for executable:", err)
+			if err := updater.Run(Version, opts); err != nil {
+				fmt.Println("Update failed:", err)
 				os.Exit(1)
 			}
-			if tmpSum != exeSum {
-				// Move the temporary file to the executable location
-				err = os.Rename(tmpFile, exePath)
-				if err != nil {
-					fmt.Println("Error replacing executable:", err)
-					os.Exit(1)
-				}
-				fmt.Printf("Update complete. New version: ")
-				newVersionCmd := exec.Command(exePath, "--version")
-				newVersionCmd.Stdout = os.Stdout
-				newVersionCmd.Stderr = os.Stderr
-				if err := newVersionCmd.Run(); err != nil {
-					fmt.Println("(error running updated binary to show version)")
-				}
-				os.Exit(0)
-			} else {
-				fmt.Printf("No update needed, already on version: %s\n", Version)
-				os.Remove(tmpFile)
-				os.Exit(0)
-			}
+			os.Exit(0)
 		}
 	}
 
@@ -225,6 +282,40 @@ Usage:
 		os.Exit(1)
 	}
 
+	if len(args) > 0 {
+		switch args[0] {
+		case "--install-service", "--uninstall-service", "--service-status", "--agent":
+			runServiceCommand(args[0], cfg)
+		}
+	}
+
+	backend := cfg.Backend
+	if cliBackend != "" {
+		backend = cliBackend
+	}
+
+	historyPath, err := history.DefaultPath()
+	if err != nil {
+		fmt.Println("Error determining history path:", err)
+		os.Exit(1)
+	}
+	store, err := history.Load(historyPath)
+	if err != nil {
+		fmt.Println("Error loading history:", err)
+		os.Exit(1)
+	}
+	prober := search.NewProber()
+
+	var soHooks []plugin.Hook
+	if !noHooks {
+		pluginsDir := filepath.Join(homeDir, ".config", "sshmenu", "plugins")
+		soHooks, err = plugin.LoadPlugins(pluginsDir)
+		if err != nil {
+			fmt.Println("Error loading plugins:", err)
+			os.Exit(1)
+		}
+	}
+
 	// Create a bell-filtered writer that wraps the real stdout
 	filteredStdout := bellFilter{w: os.Stdout}
 
@@ -244,68 +335,76 @@ Usage:
 			fmt.Println("Error loading config:", err)
 			os.Exit(1)
 		}
+		if cliBackend != "" {
+			backend = cliBackend
+		} else {
+			backend = cfg.Backend
+		}
+
+		hooks := plugin.NewRegistry()
+		if !noHooks {
+			for _, h := range soHooks {
+				hooks.Register(h, true)
+			}
+			for _, hc := range cfg.Hooks.Pre {
+				hooks.Register(plugin.NewShellPreHook(hc.Command), hc.Required)
+			}
+			for _, hc := range cfg.Hooks.Post {
+				hooks.Register(plugin.NewShellPostHook(hc.Command), hc.Required)
+			}
+		}
 
 		if searchString != "" {
-			// Flat filtered list
-			flatServers := []Server{}
+			// Flat fuzzy-ranked list across every project's servers.
+			var flatServers []Server
 			for _, p := range cfg.Projects {
-				for _, s := range p.Servers {
-					if strings.Contains(strings.ToLower(s.Name), strings.ToLower(searchString)) || strings.Contains(strings.ToLower(s.Description), strings.ToLower(searchString)) {
-						flatServers = append(flatServers, s)
-					}
-				}
+				flatServers = append(flatServers, p.Servers...)
+			}
+			byName := make(map[string]Server, len(flatServers))
+			for _, s := range flatServers {
+				byName[s.Name] = s
 			}
-			if len(flatServers) == 0 {
+			rank := func() []search.Result {
+				return search.Rank(buildSearchItems(flatServers, store, prober), searchString)
+			}
+			results := rank()
+			if len(results) == 0 {
 				fmt.Println("No servers found matching:", searchString)
 				return
 			}
-			serverNames := []string{}
-			for _, s := range flatServers {
-				serverNames = append(serverNames, s.Name+" - "+s.Description)
-			}
-			serverNames = append(serverNames, "\u23FB Quit") // ⏻ Quit
 
-			// Select server from flat list
-			serverPrompt := promptui.Select{
-				Label:       "Select Server",
-				Items:       serverNames,
-				HideHelp:    true,
-				HideSelected: true,
-				Size:        50,
-				Stdout:      filteredStdout,
+			picker := &search.Picker{
+				Results: results,
+				OnToggleFavorite: func(name string) []search.Result {
+					store.ToggleFavorite(name)
+					if err := store.Save(); err != nil {
+						fmt.Println("Warning: failed to save history:", err)
+					}
+					return rank()
+				},
+				OnForget: func(name string) []search.Result {
+					store.Forget(name)
+					if err := store.Save(); err != nil {
+						fmt.Println("Warning: failed to save history:", err)
+					}
+					return rank()
+				},
 			}
-			sidx, sresult, err := serverPrompt.Run()
+			name, action, err := picker.Run()
 			fmt.Print("\r\033[K")
-			if err == promptui.ErrInterrupt || err == promptui.ErrEOF {
-				fmt.Println("Exiting.")
-				return
-			}
 			if err != nil {
 				fmt.Println("Prompt failed:", err)
 				return
 			}
-			if sresult == "\u23FB Quit" {
+			if action == search.PickQuit {
 				fmt.Println("Exiting.")
 				return
 			}
-			// Only proceed if a real server was selected
-			if sidx < 0 || sidx >= len(flatServers) {
+			server, ok := byName[name]
+			if !ok {
 				return
 			}
-			server := flatServers[sidx]
-			cmdStr := server.Command
-			if cmdStr == "" {
-				cmdStr = cfg.GlobalCommand
-				cmdStr = replaceServer(cmdStr, server.Name)
-			}
-			fmt.Println("Running:", cmdStr)
-			cmd := exec.Command("bash", "-c", cmdStr)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			cmd.Stdin = os.Stdin
-			if err := cmd.Run(); err != nil {
-				fmt.Println("Command failed:", err)
-			}
+			runServer(cfg, server, backend, store, hooks)
 			return
 		} else {
 			// Two-step UI: project → server
@@ -379,19 +478,7 @@ Usage:
 					continue
 				}
 				server := project.Servers[sidx]
-				cmdStr := server.Command
-				if cmdStr == "" {
-					cmdStr = cfg.GlobalCommand
-					cmdStr = replaceServer(cmdStr, server.Name)
-				}
-				fmt.Println("Running:", cmdStr)
-				cmd := exec.Command("bash", "-c", cmdStr)
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				cmd.Stdin = os.Stdin
-				if err := cmd.Run(); err != nil {
-					fmt.Println("Command failed:", err)
-				}
+				runServer(cfg, server, backend, store, hooks)
 			}
 			// After server selection, exit
 			return
@@ -409,3 +496,201 @@ func replaceServer(template, server string) string {
 func stringReplace(s, old, new string) string {
 	return strings.ReplaceAll(s, old, new)
 }
+
+// runServer launches a session for server using the given backend, printing
+// what it's about to do and reporting failure the way the rest of main does.
+// The outcome is recorded in store so the next search ranks recents and
+// surfaces repeated failures. hooks runs around the session; a required
+// pre-connect hook that fails aborts the launch entirely.
+func runServer(cfg *Config, server Server, backend string, store *history.Store, hooks *plugin.Registry) {
+	target := launcherTarget(cfg, server)
+	pserver := &plugin.Server{Name: target.Name, Host: target.Host, Port: target.Port, User: target.User, Command: target.Command}
+	ctx := context.Background()
+
+	if err := hooks.PreConnect(ctx, pserver); err != nil {
+		fmt.Println("Pre-connect hook failed:", err)
+		return
+	}
+
+	l, err := launcher.New(backend)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if backend == "" || backend == "shell" {
+		fmt.Println("Running:", target.Command)
+	} else {
+		fmt.Println("Connecting to:", server.Name)
+	}
+	launchErr := l.Launch(target)
+	if launchErr != nil {
+		fmt.Println("Command failed:", launchErr)
+		store.MarkFailed(server.Name)
+	} else {
+		store.MarkUsed(server.Name, time.Now())
+	}
+	hooks.PostConnect(ctx, pserver, launchErr)
+	if err := store.Save(); err != nil {
+		fmt.Println("Warning: failed to save history:", err)
+	}
+}
+
+// buildSearchItems turns servers into search.Items decorated with each
+// server's favorite/recent/failure state from store, and its reachability
+// when it has a Host to probe. Reachability probes run concurrently (one
+// ProbeTimeout total, not one per server) so a batch of unreachable hosts
+// doesn't stall the first render.
+func buildSearchItems(servers []Server, store *history.Store, prober *search.Prober) []search.Item {
+	probeHosts := make([]string, len(servers))
+	probePorts := make([]int, len(servers))
+	var targets []search.Target
+	for i, s := range servers {
+		if s.Host == "" {
+			continue
+		}
+		host, port, _ := resolveHostPortUser(s.Host, s.Port, "")
+		if strings.HasPrefix(host, "ssh://") {
+			// Malformed ssh:// URL; nothing sane to probe.
+			continue
+		}
+		probeHosts[i], probePorts[i] = host, sshPort(port)
+		targets = append(targets, search.Target{Host: probeHosts[i], Port: probePorts[i]})
+	}
+	prober.ProbeAll(targets)
+
+	items := make([]search.Item, len(servers))
+	for i, s := range servers {
+		recentRank, isRecent := store.RecentRank(s.Name)
+		item := search.Item{
+			Name:        s.Name,
+			Description: s.Description,
+			Tags:        s.Tags,
+			Favorite:    store.IsFavorite(s.Name),
+			Recent:      isRecent,
+			RecentRank:  recentRank,
+			LastFailed:  store.LastFailed(s.Name),
+		}
+		if probeHosts[i] != "" {
+			item.ReachKnown = true
+			item.Reachable = prober.Probe(probeHosts[i], probePorts[i])
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// sshPort returns port, or the default SSH port if it's unset.
+func sshPort(port int) int {
+	if port == 0 {
+		return 22
+	}
+	return port
+}
+
+// runHistoryCommand implements `sshmenu --history [forget <name>]`.
+func runHistoryCommand(args []string) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Println("Error determining history path:", err)
+		os.Exit(1)
+	}
+	store, err := history.Load(path)
+	if err != nil {
+		fmt.Println("Error loading history:", err)
+		os.Exit(1)
+	}
+
+	if len(args) >= 2 && args[0] == "forget" {
+		store.Forget(args[1])
+		if err := store.Save(); err != nil {
+			fmt.Println("Error saving history:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Forgot:", args[1])
+		os.Exit(0)
+	}
+
+	recents := store.Recents()
+	if len(recents) == 0 {
+		fmt.Println("No recent connections.")
+		os.Exit(0)
+	}
+	for _, name := range recents {
+		if store.LastFailed(name) {
+			fmt.Println(name, "(last connection failed)")
+		} else {
+			fmt.Println(name)
+		}
+	}
+	os.Exit(0)
+}
+
+// runFavoritesCommand implements `sshmenu --favorites [add|remove <name>]`.
+func runFavoritesCommand(args []string) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Println("Error determining history path:", err)
+		os.Exit(1)
+	}
+	store, err := history.Load(path)
+	if err != nil {
+		fmt.Println("Error loading history:", err)
+		os.Exit(1)
+	}
+
+	if len(args) >= 2 && (args[0] == "add" || args[0] == "remove") {
+		if store.IsFavorite(args[1]) != (args[0] == "add") {
+			store.ToggleFavorite(args[1])
+		}
+		if err := store.Save(); err != nil {
+			fmt.Println("Error saving history:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Favorites updated.")
+		os.Exit(0)
+	}
+
+	favorites := store.Favorites()
+	if len(favorites) == 0 {
+		fmt.Println("No favorites yet.")
+		os.Exit(0)
+	}
+	for _, name := range favorites {
+		fmt.Println(name)
+	}
+	os.Exit(0)
+}
+
+// runServiceCommand implements the --install-service, --uninstall-service,
+// --service-status, and --agent subcommands. All four exit the process
+// directly; --agent only returns once the resident agent is stopped.
+func runServiceCommand(cmd string, cfg *Config) {
+	svcCfg := service.Config{TerminalCommand: cfg.TerminalCommand}
+	switch cmd {
+	case "--install-service":
+		if err := service.Install(svcCfg); err != nil {
+			fmt.Println("Error installing service:", err)
+			os.Exit(1)
+		}
+		fmt.Println("sshmenu agent installed.")
+	case "--uninstall-service":
+		if err := service.Uninstall(svcCfg); err != nil {
+			fmt.Println("Error uninstalling service:", err)
+			os.Exit(1)
+		}
+		fmt.Println("sshmenu agent uninstalled.")
+	case "--service-status":
+		status, err := service.Status(svcCfg)
+		if err != nil {
+			fmt.Println("Error getting service status:", err)
+			os.Exit(1)
+		}
+		fmt.Println("sshmenu agent:", status)
+	case "--agent":
+		if err := service.Run(svcCfg); err != nil {
+			fmt.Println("Agent error:", err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(0)
+}
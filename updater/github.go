@@ -0,0 +1,84 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// apiBase is the GitHub Releases API for this repo.
+const apiBase = "https://api.github.com/repos/vorn003/SSHMenu/releases"
+
+// release mirrors the subset of the GitHub Releases API response sshmenu
+// needs to pick and verify an asset.
+type release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []asset `json:"assets"`
+}
+
+// asset is one file attached to a release: the platform binaries,
+// SHA256SUMS, and SHA256SUMS.sig are all ordinary release assets.
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+}
+
+// assetName returns the expected release asset name for the running
+// platform, e.g. "sshmenu_darwin_arm64" or "sshmenu_windows_amd64.exe".
+func assetName() string {
+	name := fmt.Sprintf("sshmenu_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// fetchRelease returns the release to update to: the latest release on the
+// stable channel, or the most recent prerelease for any other channel name
+// (e.g. "beta").
+func fetchRelease(channel string) (release, error) {
+	if channel == "" || channel == "stable" {
+		raw, err := download(apiBase + "/latest")
+		if err != nil {
+			return release{}, err
+		}
+		var r release
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return release{}, fmt.Errorf("parsing release metadata: %w", err)
+		}
+		return r, nil
+	}
+
+	raw, err := download(apiBase)
+	if err != nil {
+		return release{}, err
+	}
+	var all []release
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return release{}, fmt.Errorf("parsing release metadata: %w", err)
+	}
+	for _, r := range all {
+		if r.Prerelease {
+			return r, nil
+		}
+	}
+	return release{}, fmt.Errorf("no prerelease found on the %s channel", channel)
+}
+
+// findAsset returns the release asset named name, or an error listing what
+// was available so a user missing a platform build knows why.
+func findAsset(r release, name string) (asset, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	available := make([]string, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		available = append(available, a.Name)
+	}
+	return asset{}, fmt.Errorf("release %s has no asset named %q; available: %s", r.TagName, name, strings.Join(available, ", "))
+}
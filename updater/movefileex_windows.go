@@ -0,0 +1,37 @@
+//go:build windows
+
+package updater
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procMoveFileExW  = modkernel32.NewProc("MoveFileExW")
+	moveFileReplace  = 0x1 // MOVEFILE_REPLACE_EXISTING
+	moveFileWriteThr = 0x8 // MOVEFILE_WRITE_THROUGH
+)
+
+// moveFileEx wraps the Win32 MoveFileExW call so the staged binary can be
+// moved over the (now vacated) executable path in one atomic step.
+func moveFileEx(src, dst string) error {
+	srcPtr, err := syscall.UTF16PtrFromString(src)
+	if err != nil {
+		return err
+	}
+	dstPtr, err := syscall.UTF16PtrFromString(dst)
+	if err != nil {
+		return err
+	}
+	ret, _, errno := procMoveFileExW.Call(
+		uintptr(unsafe.Pointer(srcPtr)),
+		uintptr(unsafe.Pointer(dstPtr)),
+		uintptr(moveFileReplace|moveFileWriteThr),
+	)
+	if ret == 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// showProgress reports whether a progress bar should be drawn: not
+// suppressed by --quiet, and stdout is an interactive terminal.
+func showProgress(quiet bool) bool {
+	if quiet {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar renders a single-line "bytes / total, throughput, ETA" bar to
+// stdout, redrawing in place.
+type progressBar struct {
+	total    int64
+	read     int64
+	start    time.Time
+	lastDraw time.Time
+}
+
+func newProgressBar(total int64) *progressBar {
+	return &progressBar{total: total, start: time.Now()}
+}
+
+// add records n more bytes transferred and redraws, throttled to avoid
+// flooding the terminal.
+func (p *progressBar) add(n int) {
+	p.read += int64(n)
+	now := time.Now()
+	if !p.lastDraw.IsZero() && now.Sub(p.lastDraw) < 100*time.Millisecond && p.read != p.total {
+		return
+	}
+	p.lastDraw = now
+	p.draw()
+}
+
+func (p *progressBar) draw() {
+	elapsed := time.Since(p.start).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.read) / elapsed
+	}
+	if p.total > 0 {
+		pct := float64(p.read) / float64(p.total) * 100
+		eta := "?"
+		if rate > 0 {
+			remaining := time.Duration(float64(p.total-p.read) / rate * float64(time.Second))
+			eta = remaining.Round(time.Second).String()
+		}
+		fmt.Printf("\rDownloading... %5.1f%%  %s/%s  %s/s  ETA %s   ",
+			pct, humanBytes(p.read), humanBytes(p.total), humanBytes(int64(rate)), eta)
+		return
+	}
+	fmt.Printf("\rDownloading... %s  %s/s   ", humanBytes(p.read), humanBytes(int64(rate)))
+}
+
+// finish draws the bar one last time at 100% and moves to a fresh line.
+func (p *progressBar) finish() {
+	p.draw()
+	fmt.Println()
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for n2 := n / unit; n2 >= unit; n2 /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// countingReader wraps an io.Reader, invoking onRead with the number of
+// bytes consumed by each Read call. Used to drive the progress bar from
+// inside an io.Copy/io.ReadAll without changing their call sites.
+type countingReader struct {
+	r      io.Reader
+	onRead func(n int)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 && c.onRead != nil {
+		c.onRead(n)
+	}
+	return n, err
+}
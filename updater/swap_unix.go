@@ -0,0 +1,10 @@
+//go:build !windows
+
+package updater
+
+// atomicSwap replaces dstPath with tmpPath. On Unix, os.Rename onto an
+// existing file (even one that is currently executing) is already atomic,
+// so there's nothing platform-specific to do.
+func atomicSwap(tmpPath, dstPath string) error {
+	return atomicSwapGeneric(tmpPath, dstPath)
+}
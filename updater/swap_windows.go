@@ -0,0 +1,28 @@
+//go:build windows
+
+package updater
+
+import (
+	"os"
+)
+
+// atomicSwap replaces dstPath with tmpPath on Windows, where a running
+// executable's image file is locked. We first rename the running exe aside
+// to "<exe>.old" (Windows allows renaming an open file, just not overwriting
+// one), then move the staged binary into place via MoveFileEx with
+// MOVEFILE_REPLACE_EXISTING. The ".old" file is left in place, since it may
+// still be mapped into this process; the next update removes it before
+// renaming.
+func atomicSwap(tmpPath, dstPath string) error {
+	oldPath := dstPath + ".old"
+	os.Remove(oldPath) // best-effort cleanup of a stale .old from a prior update
+	if err := os.Rename(dstPath, oldPath); err != nil {
+		return err
+	}
+	if err := moveFileEx(tmpPath, dstPath); err != nil {
+		// Best-effort rollback so we don't leave the user without a binary.
+		os.Rename(oldPath, dstPath)
+		return err
+	}
+	return nil
+}
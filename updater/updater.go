@@ -0,0 +1,265 @@
+// Package updater implements the self-update flow for sshmenu: it fetches a
+// release asset from GitHub, verifies it against a signed SHA256SUMS file,
+// and atomically swaps it in for the running executable.
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// PublicKeyHex is the hex-encoded ed25519 public key used to verify release
+// signatures. It is baked in at build time via:
+//
+//	-ldflags "-X github.com/vorn003/SSHMenu/updater.PublicKeyHex=<hex>"
+//
+// Builds without an embedded key refuse to apply updates.
+var PublicKeyHex = ""
+
+// Options configures an update run.
+type Options struct {
+	// Channel selects the release channel: "" (or "stable") for the latest
+	// release, "beta" for the latest prerelease.
+	Channel string
+	// DryRun reports what would happen without downloading or swapping.
+	DryRun bool
+	// Quiet suppresses the download progress bar.
+	Quiet bool
+	// Timeout bounds the release asset download. The release metadata,
+	// SHA256SUMS and signature fetches are not bounded by it. Zero means no
+	// timeout.
+	Timeout time.Duration
+}
+
+// HTTPGet is overridable by tests. It's used for the small, fixed-size
+// fetches (release metadata, SHA256SUMS, signature).
+var HTTPGet = http.Get
+
+// HTTPDo is overridable by tests. It's used for the asset download, which
+// needs per-call context (for --timeout) and a response it can wrap in a
+// progress bar.
+var HTTPDo = func(req *http.Request) (*http.Response, error) {
+	return http.DefaultClient.Do(req)
+}
+
+func releaseTag(channel string) string {
+	if channel == "" || channel == "stable" {
+		return "stable"
+	}
+	return channel
+}
+
+// Run performs (or, in dry-run mode, previews) an update of the running
+// executable to the latest release on opts.Channel.
+func Run(currentVersion string, opts Options) error {
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	rel, err := fetchRelease(opts.Channel)
+	if err != nil {
+		return fmt.Errorf("checking for updates: %w", err)
+	}
+	name := assetName()
+	bin, err := findAsset(rel, name)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Printf("Current version: %s\n", currentVersion)
+		fmt.Printf("Channel: %s\n", releaseTag(opts.Channel))
+		fmt.Printf("Latest release: %s\n", rel.TagName)
+		fmt.Printf("Platform asset: %s (%s)\n", bin.Name, humanBytes(bin.Size))
+		if rel.TagName == currentVersion {
+			fmt.Println("Already up to date; no update would be applied.")
+		} else {
+			fmt.Println("An update would be applied.")
+		}
+		return nil
+	}
+
+	if rel.TagName == currentVersion {
+		fmt.Println("No update needed.")
+		return nil
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("determining executable path: %w", err)
+	}
+
+	fmt.Printf("Downloading %s (%s)...\n", rel.TagName, name)
+	asset, err := downloadAsset(ctx, bin.BrowserDownloadURL, bin.Size, opts.Quiet)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	fmt.Println("Verifying checksum and signature...")
+	sumsAsset, err := findAsset(rel, "SHA256SUMS")
+	if err != nil {
+		return err
+	}
+	sigAsset, err := findAsset(rel, "SHA256SUMS.sig")
+	if err != nil {
+		return err
+	}
+	sums, err := downloadChecksums(sumsAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("downloading SHA256SUMS: %w", err)
+	}
+	sig, err := download(sigAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("downloading SHA256SUMS.sig: %w", err)
+	}
+	if err := verifySignature(sums, sig); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	wantSum, ok := sums.entries[name]
+	if !ok {
+		return fmt.Errorf("SHA256SUMS has no entry for %s", name)
+	}
+	if err := verifyChecksum(asset, wantSum); err != nil {
+		return fmt.Errorf("checksum verification failed: %w", err)
+	}
+
+	tmpPath := exePath + ".new"
+	if err := os.WriteFile(tmpPath, asset, 0o755); err != nil {
+		return fmt.Errorf("writing staged binary: %w", err)
+	}
+
+	if err := atomicSwap(tmpPath, exePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("installing update: %w", err)
+	}
+
+	fmt.Println("Update complete.")
+	return nil
+}
+
+// download fetches url and returns the full response body.
+func download(url string) ([]byte, error) {
+	resp, err := HTTPGet(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+	if ct := resp.Header.Get("Content-Type"); strings.Contains(ct, "text/html") {
+		return nil, fmt.Errorf("%s returned HTML, not the expected artifact", url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// downloadAsset fetches the release binary itself. Unlike download, it
+// carries a context (so --timeout can cancel it) and, unless quiet, renders
+// a progress bar as it streams the body.
+func downloadAsset(ctx context.Context, url string, size int64, quiet bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := HTTPDo(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+	if ct := resp.Header.Get("Content-Type"); strings.Contains(ct, "text/html") {
+		return nil, fmt.Errorf("%s returned HTML, not the expected artifact", url)
+	}
+
+	total := resp.ContentLength
+	if total <= 0 {
+		total = size
+	}
+
+	var body io.Reader = resp.Body
+	if showProgress(quiet) {
+		bar := newProgressBar(total)
+		body = &countingReader{r: resp.Body, onRead: bar.add}
+		defer bar.finish()
+	}
+	return io.ReadAll(body)
+}
+
+// checksums holds the parsed contents of a SHA256SUMS file plus the raw
+// bytes, since the raw bytes are what the signature covers.
+type checksums struct {
+	raw     []byte
+	entries map[string]string
+}
+
+func downloadChecksums(url string) (checksums, error) {
+	raw, err := download(url)
+	if err != nil {
+		return checksums{}, err
+	}
+	entries := map[string]string{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return checksums{}, fmt.Errorf("malformed SHA256SUMS line: %q", line)
+		}
+		entries[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums{raw: raw, entries: entries}, nil
+}
+
+func verifySignature(sums checksums, sig []byte) error {
+	if PublicKeyHex == "" {
+		return errors.New("no public key embedded in this build; refusing to verify")
+	}
+	key, err := hex.DecodeString(PublicKeyHex)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("embedded public key is invalid")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), sums.raw, sig) {
+		return errors.New("SHA256SUMS signature does not match embedded public key")
+	}
+	return nil
+}
+
+func verifyChecksum(data []byte, wantHex string) error {
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(got, wantHex) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, wantHex)
+	}
+	return nil
+}
+
+// atomicSwapGeneric refuses an empty staged file and otherwise renames
+// tmpPath over dstPath in a single os.Rename; no copy of the old executable
+// is kept. Platform-specific swap logic (e.g. Windows' MoveFileEx dance) lives in
+// swap_unix.go / swap_windows.go.
+func atomicSwapGeneric(tmpPath, dstPath string) error {
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return errors.New("staged binary is empty")
+	}
+	return os.Rename(tmpPath, dstPath)
+}
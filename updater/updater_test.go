@@ -0,0 +1,219 @@
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeResponse builds an *http.Response for a fixed body, the way the real
+// http.Client would for a 200 OK.
+func fakeResponse(body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+		Header:     http.Header{},
+	}
+}
+
+func sumsAndSig(t *testing.T, asset []byte, pub ed25519.PublicKey, priv ed25519.PrivateKey) (sums []byte, sig []byte) {
+	t.Helper()
+	sum := sha256.Sum256(asset)
+	sums = []byte(hex.EncodeToString(sum[:]) + "  " + assetName() + "\n")
+	sig = ed25519.Sign(priv, sums)
+	return sums, sig
+}
+
+// withFetchers swaps in a download map keyed by URL suffix and restores the
+// real HTTPGet when the test finishes.
+func withFetchers(t *testing.T, byAsset func(url string) (*http.Response, error)) {
+	t.Helper()
+	orig := HTTPGet
+	HTTPGet = byAsset
+	t.Cleanup(func() { HTTPGet = orig })
+}
+
+func TestDownloadAndVerify_Success(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	PublicKeyHex = hex.EncodeToString(pub)
+	t.Cleanup(func() { PublicKeyHex = "" })
+
+	asset := []byte("totally a real binary")
+	sums, sig := sumsAndSig(t, asset, pub, priv)
+
+	withFetchers(t, func(url string) (*http.Response, error) {
+		switch {
+		case strings.HasSuffix(url, assetName()):
+			return fakeResponse(asset), nil
+		case strings.HasSuffix(url, "SHA256SUMS"):
+			return fakeResponse(sums), nil
+		case strings.HasSuffix(url, "SHA256SUMS.sig"):
+			return fakeResponse(sig), nil
+		}
+		return nil, errors.New("unexpected url: " + url)
+	})
+
+	// Run() itself operates on os.Executable(), which isn't overridable from
+	// a test without an exec shim, so we exercise the download/verify
+	// helpers it calls directly and leave the end-to-end path to manual and
+	// --dry-run testing.
+	got := sha256.Sum256(asset)
+	wantHex := hex.EncodeToString(got[:])
+	sc, err := downloadChecksums("https://example.invalid/SHA256SUMS")
+	if err != nil {
+		t.Fatalf("downloadChecksums: %v", err)
+	}
+	if sc.entries[assetName()] != wantHex {
+		t.Fatalf("parsed checksum = %q, want %q", sc.entries[assetName()], wantHex)
+	}
+	if err := verifySignature(sc, sig); err != nil {
+		t.Fatalf("verifySignature: %v", err)
+	}
+	if err := verifyChecksum(asset, wantHex); err != nil {
+		t.Fatalf("verifyChecksum: %v", err)
+	}
+}
+
+func TestVerifyChecksum_Tampered(t *testing.T) {
+	asset := []byte("original contents")
+	sum := sha256.Sum256(asset)
+	wantHex := hex.EncodeToString(sum[:])
+
+	tampered := []byte("original contents, but evil")
+	if err := verifyChecksum(tampered, wantHex); err == nil {
+		t.Fatal("expected checksum mismatch for tampered artifact, got nil error")
+	}
+}
+
+func TestVerifySignature_WrongKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	PublicKeyHex = hex.EncodeToString(otherPub)
+	t.Cleanup(func() { PublicKeyHex = "" })
+
+	sums := checksums{raw: []byte("deadbeef  sshmenu_linux_amd64\n")}
+	sig := ed25519.Sign(priv, sums.raw)
+
+	if err := verifySignature(sums, sig); err == nil {
+		t.Fatal("expected signature verification to fail for mismatched key, got nil error")
+	}
+}
+
+func TestAtomicSwap(t *testing.T) {
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "sshmenu")
+	tmpPath := exePath + ".new"
+	if err := os.WriteFile(exePath, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmpPath, []byte("new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := atomicSwap(tmpPath, exePath); err != nil {
+		t.Fatalf("atomicSwap: %v", err)
+	}
+	data, err := os.ReadFile(exePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("exePath contains %q after swap, want %q", data, "new")
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Fatalf("staged file still present after swap: %v", err)
+	}
+}
+
+func TestFindAsset_MissingPlatform(t *testing.T) {
+	rel := release{
+		TagName: "v1.2.3",
+		Assets: []asset{
+			{Name: "sshmenu_linux_amd64"},
+			{Name: "sshmenu_darwin_amd64"},
+		},
+	}
+	_, err := findAsset(rel, "sshmenu_windows_amd64.exe")
+	if err == nil {
+		t.Fatal("expected an error for a platform with no matching asset, got nil")
+	}
+	if !strings.Contains(err.Error(), "sshmenu_linux_amd64") {
+		t.Fatalf("error should list available assets, got: %v", err)
+	}
+}
+
+func TestFindAsset_Match(t *testing.T) {
+	rel := release{Assets: []asset{
+		{Name: "sshmenu_linux_amd64", BrowserDownloadURL: "https://example.invalid/linux"},
+	}}
+	a, err := findAsset(rel, "sshmenu_linux_amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.BrowserDownloadURL != "https://example.invalid/linux" {
+		t.Fatalf("got %q", a.BrowserDownloadURL)
+	}
+}
+
+func TestDownloadAsset_ContextCanceled(t *testing.T) {
+	origDo := HTTPDo
+	HTTPDo = func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	t.Cleanup(func() { HTTPDo = origDo })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := downloadAsset(ctx, "https://example.invalid/asset", 0, true); err == nil {
+		t.Fatal("expected downloadAsset to return an error once its context is canceled")
+	}
+}
+
+func TestDownload_InterruptedBody(t *testing.T) {
+	withFetchers(t, func(url string) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(&shortReader{data: []byte("trunc")}),
+			Header:     http.Header{},
+		}, nil
+	})
+	if _, err := download("https://example.invalid/asset"); err == nil {
+		t.Fatal("expected an error for a connection that closes mid-download, got nil")
+	}
+}
+
+// shortReader simulates a connection that is closed mid-read by returning
+// io.ErrUnexpectedEOF after its fixed payload.
+type shortReader struct {
+	data []byte
+	off  int
+}
+
+func (r *shortReader) Read(p []byte) (int, error) {
+	if r.off >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data[r.off:])
+	r.off += n
+	return n, nil
+}